@@ -5,17 +5,32 @@ import (
 	"log"
 	"fmt"
 	"os"
-	
+
 	"github.com/paulsmith/gossip"
 )
 
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [serve] src dest\n", os.Args[0])
+	os.Exit(1)
+}
+
 func main() {
+	addr := flag.String("addr", ":8000", "address to serve on, with the serve subcommand")
 	flag.Parse()
-	if flag.NArg() != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s src dest\n", os.Args[0])
-		os.Exit(1)
+
+	args := flag.Args()
+	if len(args) == 3 && args[0] == "serve" {
+		site := gossip.NewSite(args[1], args[2])
+		if err := site.Serve(*addr); err != nil {
+			log.Fatalf("error serving site: %v", err)
+		}
+		return
+	}
+
+	if len(args) != 2 {
+		usage()
 	}
-	site := gossip.NewSite(flag.Arg(0), flag.Arg(1))
+	site := gossip.NewSite(args[0], args[1])
 	if err := site.Generate(); err != nil {
 		log.Fatalf("error generating site: %v", err)
 	}
@@ -0,0 +1,161 @@
+package gossip
+
+import (
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// feedEntry is the per-post data exposed to feed and sitemap templates.
+type feedEntry struct {
+	Title   string
+	Link    string
+	Updated time.Time
+	Content string
+}
+
+// feedData is passed to atom.xml, rss.xml, and sitemap.xml templates. The
+// same feed entries reach default.html as Context.Posts, so index pages can
+// list recent posts.
+type feedData struct {
+	Site    *Site
+	Posts   []feedEntry
+	BuiltAt time.Time
+}
+
+// feedFuncs are the template functions available to atom.xml, rss.xml, and
+// sitemap.xml, whether built-in or user-supplied. text/template (the right
+// choice for XML output, unlike html/template) doesn't escape for us, so
+// any value that might contain "&", "<", or ">" needs to pass through
+// xmlesc explicitly.
+var feedFuncs = template.FuncMap{
+	"xmlesc": html.EscapeString,
+}
+
+const defaultAtomTemplate = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>{{.Site.Title | xmlesc}}</title>
+  <link href="{{.Site.BaseURL | xmlesc}}"/>
+  <updated>{{.BuiltAt.Format "2006-01-02T15:04:05Z07:00"}}</updated>
+  <author><name>{{.Site.Author | xmlesc}}</name></author>
+  <id>{{.Site.BaseURL | xmlesc}}</id>
+  {{range .Posts}}
+  <entry>
+    <title>{{.Title | xmlesc}}</title>
+    <link href="{{.Link | xmlesc}}"/>
+    <id>{{.Link | xmlesc}}</id>
+    <updated>{{.Updated.Format "2006-01-02T15:04:05Z07:00"}}</updated>
+    <content type="html">{{.Content | xmlesc}}</content>
+  </entry>
+  {{end}}
+</feed>
+`
+
+const defaultRSSTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>{{.Site.Title | xmlesc}}</title>
+    <link>{{.Site.BaseURL | xmlesc}}</link>
+    <description>{{.Site.Title | xmlesc}}</description>
+    {{range .Posts}}
+    <item>
+      <title>{{.Title | xmlesc}}</title>
+      <link>{{.Link | xmlesc}}</link>
+      <guid>{{.Link | xmlesc}}</guid>
+      <pubDate>{{.Updated.Format "Mon, 02 Jan 2006 15:04:05 -0700"}}</pubDate>
+    </item>
+    {{end}}
+  </channel>
+</rss>
+`
+
+const defaultSitemapTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  {{range .Posts}}
+  <url>
+    <loc>{{.Link | xmlesc}}</loc>
+    <lastmod>{{.Updated.Format "2006-01-02"}}</lastmod>
+  </url>
+  {{end}}
+</urlset>
+`
+
+// feedEntriesFromPosts builds the feed entries for posts, sorted newest
+// first, using s.BaseURL to form each post's permalink.
+func feedEntriesFromPosts(s *Site, posts []*Post) []feedEntry {
+	entries := make([]feedEntry, len(posts))
+	for i, post := range posts {
+		content := ""
+		if rendered, err := post.render(); err == nil {
+			content = string(rendered)
+		}
+		entries[i] = feedEntry{
+			Title:   post.Title,
+			Link:    s.permalink(post),
+			Updated: post.pubdate,
+			Content: content,
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Updated.After(entries[j].Updated)
+	})
+	return entries
+}
+
+// permalink builds the absolute URL for a post under s.BaseURL, mirroring
+// the /year/month/file.html layout generatePosts writes to Dest.
+func (s *Site) permalink(post *Post) string {
+	year, month := post.dateParts()
+	return s.BaseURL + "/" + filepath.Join(year, month, post.destFileName)
+}
+
+// generateFeeds writes atom.xml, rss.xml, and sitemap.xml to s.Dest from
+// posts, newest first. Each is rendered from a template in the site's
+// templates dir when present, or a built-in default otherwise.
+func (s *Site) generateFeeds(posts []feedEntry) error {
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Updated.After(posts[j].Updated)
+	})
+
+	data := feedData{Site: s, Posts: posts, BuiltAt: time.Now()}
+
+	files := []struct {
+		name    string
+		builtin string
+	}{
+		{"atom.xml", defaultAtomTemplate},
+		{"rss.xml", defaultRSSTemplate},
+		{"sitemap.xml", defaultSitemapTemplate},
+	}
+
+	for _, f := range files {
+		tmpl, err := s.feedTemplate(f.name, f.builtin)
+		if err != nil {
+			return err
+		}
+		out, err := os.Create(filepath.Join(s.Dest, f.name))
+		if err != nil {
+			return err
+		}
+		err = tmpl.Execute(out, data)
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// feedTemplate loads name from the site's templates dir if present,
+// falling back to builtin otherwise.
+func (s *Site) feedTemplate(name, builtin string) (*template.Template, error) {
+	path := filepath.Join(s.Source, "templates", name)
+	if exists(path) {
+		return template.New(name).Funcs(feedFuncs).ParseFiles(path)
+	}
+	return template.New(name).Funcs(feedFuncs).Parse(builtin)
+}
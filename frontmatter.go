@@ -0,0 +1,161 @@
+package gossip
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// frontMatter holds the metadata parsed from a post's leading front-matter
+// block, before it's folded into a Post.
+type frontMatter struct {
+	Title      string                 `yaml:"title" toml:"title"`
+	Date       string                 `yaml:"date" toml:"date"`
+	Tags       []string               `yaml:"tags" toml:"tags"`
+	Categories []string               `yaml:"categories" toml:"categories"`
+	Slug       string                 `yaml:"slug" toml:"slug"`
+	Draft      bool                   `yaml:"draft" toml:"draft"`
+	Params     map[string]interface{} `yaml:"-" toml:"-"`
+}
+
+// frontMatterDateFormats are tried in order when parsing the "date" key,
+// since authors write front-matter by hand in whichever of these feels
+// natural.
+var frontMatterDateFormats = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// splitFrontMatter looks for a leading "---\n...\n---\n" (YAML) or
+// "+++\n...\n+++\n" (TOML) delimited block at the start of content and
+// returns the parsed front matter along with the remaining body. If content
+// has no recognizable front-matter block, it returns a zero frontMatter and
+// the content unchanged.
+func splitFrontMatter(content []byte) (fm frontMatter, body []byte, err error) {
+	body = content
+
+	delim, raw, rest, ok := extractDelimitedBlock(content)
+	if !ok {
+		return fm, body, nil
+	}
+
+	switch delim {
+	case "---":
+		var m map[string]interface{}
+		if err = yaml.Unmarshal(raw, &m); err != nil {
+			return fm, content, err
+		}
+		fm = frontMatterFromMap(m)
+	case "+++":
+		var m map[string]interface{}
+		if err = toml.Unmarshal(raw, &m); err != nil {
+			return fm, content, err
+		}
+		fm = frontMatterFromMap(m)
+	}
+
+	return fm, rest, nil
+}
+
+// extractDelimitedBlock finds a fenced block at the start of content bounded
+// by a repeated three-character delimiter ("---" or "+++") on its own line,
+// returning the delimiter, the raw bytes between the fences, and the
+// remaining content after the closing fence.
+func extractDelimitedBlock(content []byte) (delim string, raw []byte, rest []byte, ok bool) {
+	for _, d := range []string{"---", "+++"} {
+		fence := []byte(d + "\n")
+		if !bytes.HasPrefix(content, fence) {
+			continue
+		}
+		end := bytes.Index(content[len(fence):], []byte("\n"+d))
+		if end < 0 {
+			continue
+		}
+		rawStart := len(fence)
+		rawEnd := rawStart + end + 1 // include trailing newline before closing fence
+		closeStart := rawEnd + len(d)
+		rest := content[closeStart:]
+		rest = bytes.TrimPrefix(rest, []byte("\n"))
+		return d, content[rawStart : rawEnd-1], rest, true
+	}
+	return "", nil, content, false
+}
+
+// frontMatterFromMap builds a frontMatter from a generic decoded map,
+// pulling out the well-known fields and stashing everything else in Params
+// so templates can reach arbitrary front-matter keys.
+func frontMatterFromMap(m map[string]interface{}) frontMatter {
+	fm := frontMatter{Params: map[string]interface{}{}}
+	for k, v := range m {
+		switch k {
+		case "title":
+			fm.Title, _ = v.(string)
+		case "date":
+			fm.Date = toDateString(v)
+		case "slug":
+			fm.Slug, _ = v.(string)
+		case "draft":
+			fm.Draft, _ = toBool(v)
+		case "tags":
+			fm.Tags = toStringSlice(v)
+		case "categories":
+			fm.Categories = toStringSlice(v)
+		default:
+			fm.Params[k] = v
+		}
+	}
+	return fm
+}
+
+// toDateString normalizes a decoded "date" front-matter value to a string
+// parseDate can handle. TOML decodes a bare date/datetime (e.g. 2024-03-01
+// with no quotes) to a time.Time rather than a string, so that case is
+// formatted back out; a string (which is what YAML yields for a date
+// decoded into an interface{}) is taken as is.
+func toDateString(v interface{}) string {
+	switch d := v.(type) {
+	case time.Time:
+		return d.Format(time.RFC3339)
+	case string:
+		return d
+	default:
+		return ""
+	}
+}
+
+func toBool(v interface{}) (bool, bool) {
+	b, ok := v.(bool)
+	return b, ok
+}
+
+func toStringSlice(v interface{}) []string {
+	switch vs := v.(type) {
+	case []string:
+		return vs
+	case []interface{}:
+		out := make([]string, 0, len(vs))
+		for _, item := range vs {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// parseDate tries each of frontMatterDateFormats in turn, returning the
+// first successful parse.
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range frontMatterDateFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
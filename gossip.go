@@ -10,41 +10,33 @@ import (
 	"strings"
 	"text/template"
 	"time"
-
-	"github.com/russross/blackfriday"
-)
-
-// Format represents a source markup that can be converted to some output
-// (namely HTML)
-type Format int
-
-const (
-	MARKDOWN Format = iota
-	HTML
 )
 
-var formats = map[string]Format{
-	"txt":  MARKDOWN,
-	"md":   MARKDOWN,
-	"html": HTML,
-}
-
-// Convert converts a byte slice in a particular format to the format's output
-// (namely HTML)
-func (f Format) Convert(input []byte) []byte {
-	switch f {
-	case MARKDOWN:
-		return blackfriday.MarkdownBasic(input)
-	default:
-		return input
-	}
-}
-
 // Site is a generated static site, written to "Dest" from source files at
 // "Source"
 type Site struct {
 	Source string
 	Dest   string
+
+	// IncludeDrafts controls whether posts with "draft: true" front-matter
+	// are generated. Drafts are skipped by default.
+	IncludeDrafts bool
+
+	// ChromaStyle names the Chroma style used to highlight fenced code
+	// blocks in Markdown posts (e.g. "monokai"). An empty value uses the
+	// Goldmark renderer's default.
+	ChromaStyle string
+
+	// BaseURL, Title, and Author are site-wide metadata used to populate
+	// atom.xml, rss.xml, and sitemap.xml, and made available to templates
+	// via Context.Site.
+	BaseURL string
+	Title   string
+	Author  string
+
+	// Paginate is how many posts appear per page of a tag, category,
+	// archive, or index listing. Zero means defaultPaginate.
+	Paginate int
 }
 
 func NewSite(source, dest string) *Site {
@@ -54,65 +46,125 @@ func NewSite(source, dest string) *Site {
 	if dest == "" {
 		dest = "./_site"
 	}
-	return &Site{source, dest}
+	return &Site{Source: source, Dest: dest}
 }
 
 func (s *Site) Generate() error {
-	err := s.copyTree()
+	os.MkdirAll(s.Dest, 0755)
+	manifest := loadManifest(s.Dest)
+
+	matcher, err := NewMatcher(s.Source)
 	if err != nil {
 		return err
 	}
 
-	err = s.generatePosts()
+	if err := s.copyTree(manifest, matcher); err != nil {
+		return err
+	}
+
+	posts, err := s.loadPosts(matcher)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if err := s.generatePosts(posts, manifest); err != nil {
+		return err
+	}
+
+	if err := s.generateFeeds(feedEntriesFromPosts(s, posts)); err != nil {
+		return err
+	}
+
+	if err := s.generateTaxonomies(posts); err != nil {
+		return err
+	}
+
+	return manifest.save(s.Dest)
 }
 
-func (s *Site) generatePosts() error {
+// loadPosts reads every non-draft post (or every post, if s.IncludeDrafts)
+// from the posts directory, skipping any that matcher excludes.
+func (s *Site) loadPosts(matcher *Matcher) ([]*Post, error) {
 	postsDir := filepath.Join(s.Source, "posts")
-	tmplDir := filepath.Join(s.Source, "templates")
-	if !exists(postsDir) || !exists(tmplDir) {
-		return errors.New("gossip: posts and templates directories must exist")
+	if !exists(postsDir) {
+		return nil, errors.New("gossip: posts and templates directories must exist")
 	}
-	tmpl := template.Must(template.ParseFiles(filepath.Join(tmplDir, "default.html")))
 	entries, err := ioutil.ReadDir(postsDir)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	var posts []*Post
 	for _, e := range entries {
 		if strings.HasPrefix(e.Name(), ".") {
 			continue
 		}
-
-		path := filepath.Join(postsDir, e.Name())
-
-		post, err := NewPostFromPath(path)
+		rel := filepath.Join("posts", e.Name())
+		if matcher.Match(rel, e.IsDir()) {
+			continue
+		}
+		post, err := NewPostFromPath(filepath.Join(postsDir, e.Name()))
 		if err != nil {
-			return err
+			return nil, err
 		}
+		if post.Draft && !s.IncludeDrafts {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
 
+func (s *Site) generatePosts(posts []*Post, manifest *buildManifest) error {
+	tmplDir := filepath.Join(s.Source, "templates")
+	if !exists(tmplDir) {
+		return errors.New("gossip: posts and templates directories must exist")
+	}
+	if s.ChromaStyle != "" {
+		RegisterRenderer("md", NewGoldmarkRenderer(s.ChromaStyle))
+		RegisterRenderer("txt", NewGoldmarkRenderer(s.ChromaStyle))
+	}
+	tmplPath := filepath.Join(tmplDir, "default.html")
+	tmpl := template.Must(template.ParseFiles(tmplPath))
+	tmplHash, err := hashFile(tmplPath)
+	if err != nil {
+		return err
+	}
+
+	recent := feedEntriesFromPosts(s, posts)
+
+	for _, post := range posts {
 		year, month := post.dateParts()
+		rel := filepath.Join(year, month, post.destFileName)
+		hash := combineHashes(hashBytes(post.raw), tmplHash)
+
+		if manifest.upToDate(rel, hash) && exists(filepath.Join(s.Dest, rel)) {
+			continue
+		}
+
 		dir := filepath.Join(s.Dest, year, month)
 		os.MkdirAll(dir, 0755)
 
-		f, err := os.Create(filepath.Join(dir, post.destFileName))
+		f, err := os.Create(filepath.Join(s.Dest, rel))
+		if err != nil {
+			return err
+		}
+
+		err = post.Generate(f, tmpl, s, recent)
+		f.Close()
 		if err != nil {
 			return err
 		}
-		defer f.Close()
 
-		post.Generate(f, tmpl)
+		manifest.record(rel, hash)
 	}
 	return nil
 }
 
 // copyTree recursively copies files from the source dir to the dest, preserving
 // directory structure, and skipping site-specific directories like "posts" and
-// "templates"
-func (s *Site) copyTree() error {
+// "templates", anything matcher excludes, and files whose hash hasn't
+// changed since the last run (per manifest).
+func (s *Site) copyTree(manifest *buildManifest, matcher *Matcher) error {
 	ok := true
 	filepath.Walk(s.Source, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -132,14 +184,29 @@ func (s *Site) copyTree() error {
 		if strings.HasPrefix(rel, ".") || strings.Contains(rel, "/.") {
 			return nil
 		}
+		if rel != "." && matcher.Match(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		destName := filepath.Join(s.Dest, rel)
 		if info.IsDir() {
 			os.Mkdir(destName, info.Mode())
 		} else {
+			hash, err := hashFile(path)
+			if err != nil {
+				ok = false
+				return err
+			}
+			if manifest.upToDate(rel, hash) && exists(destName) {
+				return nil
+			}
 			if _, err := copyFile(path, destName); err != nil {
 				ok = false
 				return err
 			}
+			manifest.record(rel, hash)
 		}
 		return nil
 	})
@@ -179,10 +246,21 @@ func copyFile(srcName, destName string) (written int64, err error) {
 // Post is a blog post entry
 type Post struct {
 	content      []byte
+	raw          []byte
 	pubdate      time.Time
 	destFileName string
-	format       Format
+	ext          string
 	fileInfo     os.FileInfo
+
+	// Title, Tags, Categories, Slug, Draft and Params come from the post's
+	// front-matter, when present. Params holds any front-matter keys not
+	// otherwise recognized, for template authors to reach directly.
+	Title      string
+	Tags       []string
+	Categories []string
+	Slug       string
+	Draft      bool
+	Params     map[string]interface{}
 }
 
 func NewPostFromPath(path string) (*Post, error) {
@@ -191,29 +269,49 @@ func NewPostFromPath(path string) (*Post, error) {
 		return nil, err
 	}
 
-	content, err := ioutil.ReadFile(path)
+	raw, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
 	idx := strings.LastIndex(fi.Name(), ".")
-	newName := fi.Name()[:idx] + ".html"
-
 	ext := fi.Name()[idx+1:]
-	fmt, ok := formats[ext]
-	if !ok {
-		return nil, errors.New("unknown format " + ext)
+	if ext != "html" {
+		if _, ok := renderers[ext]; !ok {
+			return nil, errors.New("unknown format " + ext)
+		}
+	}
+
+	fm, content, err := splitFrontMatter(raw)
+	if err != nil {
+		return nil, fmt.Errorf("gossip: parsing front matter in %s: %w", path, err)
 	}
 
-	// TODO: get pubdate from contents
 	pubdate := fi.ModTime()
+	if fm.Date != "" {
+		if t, ok := parseDate(fm.Date); ok {
+			pubdate = t
+		}
+	}
+
+	newName := fi.Name()[:idx] + ".html"
+	if fm.Slug != "" {
+		newName = fm.Slug + ".html"
+	}
 
 	return &Post{
 		content:      content,
+		raw:          raw,
 		pubdate:      pubdate,
 		fileInfo:     fi,
 		destFileName: newName,
-		format:       fmt,
+		ext:          ext,
+		Title:        fm.Title,
+		Tags:         fm.Tags,
+		Categories:   fm.Categories,
+		Slug:         fm.Slug,
+		Draft:        fm.Draft,
+		Params:       fm.Params,
 	}, nil
 }
 
@@ -223,12 +321,55 @@ func (p *Post) dateParts() (year, month string) {
 	return
 }
 
-func (p *Post) Generate(wr io.Writer, tmpl *template.Template) error {
-	fmtContent := p.format.Convert(p.content)
-	return tmpl.Execute(wr, struct{ Content string }{string(fmtContent)})
+func (p *Post) Generate(wr io.Writer, tmpl *template.Template, site *Site, recent []feedEntry) error {
+	rendered, err := p.render()
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(wr, p.context(string(rendered), site, recent))
+}
+
+// render converts the post's content to HTML using the Renderer registered
+// for its file extension, or passes it through unchanged for ".html" posts.
+func (p *Post) render() ([]byte, error) {
+	if p.ext == "html" {
+		return p.content, nil
+	}
+	r, ok := renderers[p.ext]
+	if !ok {
+		return nil, errors.New("unknown format " + p.ext)
+	}
+	return r.Render(p.content)
+}
+
+func (p *Post) context(content string, site *Site, recent []feedEntry) Context {
+	return Context{
+		Content:    content,
+		Title:      p.Title,
+		Date:       p.pubdate,
+		Tags:       p.Tags,
+		Categories: p.Categories,
+		Slug:       p.Slug,
+		Draft:      p.Draft,
+		Params:     p.Params,
+		Site:       site,
+		Posts:      recent,
+	}
 }
 
-// Context is the object passed to the template for rendering
+// Context is the object passed to the template for rendering. It carries
+// the rendered post content alongside its front-matter fields, a reference
+// to the Site for site-wide metadata, and the full list of posts (newest
+// first) so index pages can list recent posts.
 type Context struct {
-	Content string
+	Content    string
+	Title      string
+	Date       time.Time
+	Tags       []string
+	Categories []string
+	Slug       string
+	Draft      bool
+	Params     map[string]interface{}
+	Site       *Site
+	Posts      []feedEntry
 }
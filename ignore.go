@@ -0,0 +1,188 @@
+package gossip
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileName is the name of gossip's ignore file, consulted the same
+// way git consults .gitignore: one at the source root, plus any nested in
+// subdirectories, each scoped to the directory it lives in.
+const ignoreFileName = ".gossipignore"
+
+// ignoreRule is one parsed line of a .gossipignore file.
+type ignoreRule struct {
+	pattern  string // cleaned of leading "!" and trailing "/"
+	negate   bool
+	dirOnly  bool
+	anchored bool   // pattern contained a "/" other than a trailing one
+	scope    string // dir (relative to the source root) the rule's file lives in
+}
+
+// Matcher holds the ignore rules collected from a .gossipignore at the
+// source root and any nested in subdirectories, and decides whether a
+// given path should be excluded from a site build.
+//
+// Rules follow gitignore semantics: a leading "!" negates a pattern, "**"
+// matches any depth, a trailing "/" restricts the rule to directories, and
+// later rules (including ones from a more deeply nested .gossipignore)
+// override earlier ones for the same path.
+type Matcher struct {
+	rules []ignoreRule
+}
+
+// NewMatcher builds a Matcher from every .gossipignore file found under
+// root, including root itself.
+func NewMatcher(root string) (*Matcher, error) {
+	m := &Matcher{}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if info.Name() != ignoreFileName {
+			return nil
+		}
+		scope, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		if scope == "." {
+			scope = ""
+		}
+		rules, err := parseIgnoreFile(p, scope)
+		if err != nil {
+			return err
+		}
+		m.rules = append(m.rules, rules...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseIgnoreFile parses one .gossipignore file, scoping every rule it
+// contains to scope (the rule file's directory, relative to the source
+// root).
+func parseIgnoreFile(path, scope string) ([]ignoreRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules = append(rules, parseIgnoreLine(line, scope))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func parseIgnoreLine(line, scope string) ignoreRule {
+	r := ignoreRule{scope: scope}
+
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.HasPrefix(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+		r.anchored = true
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+	}
+	r.pattern = line
+	return r
+}
+
+// Match reports whether relPath (slash-separated, relative to the source
+// root) should be ignored. isDir indicates whether relPath names a
+// directory, since some rules apply only to directories.
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	ignored := false
+	relPath = filepath.ToSlash(relPath)
+	for _, r := range m.rules {
+		if r.scope != "" && relPath != r.scope && !strings.HasPrefix(relPath, r.scope+"/") {
+			continue
+		}
+		if r.dirOnly && !isDir {
+			continue
+		}
+		scoped := relPath
+		if r.scope != "" {
+			scoped = strings.TrimPrefix(relPath, r.scope+"/")
+		}
+		if matchIgnorePattern(r.pattern, scoped, r.anchored) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matchIgnorePattern matches a single gitignore-style pattern against
+// path. An anchored pattern must match path from its start; an unanchored
+// one may match starting at any path component.
+func matchIgnorePattern(pattern, p string, anchored bool) bool {
+	patSegs := strings.Split(pattern, "/")
+	pathSegs := strings.Split(p, "/")
+
+	if anchored {
+		return matchSegments(patSegs, pathSegs)
+	}
+	for i := range pathSegs {
+		if matchSegments(patSegs, pathSegs[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches a "/"-split glob pattern (where a "**" segment
+// matches zero or more path segments) against a "/"-split path.
+func matchSegments(pat, name []string) bool {
+	if len(pat) == 0 {
+		return len(name) == 0
+	}
+	if pat[0] == "**" {
+		if len(pat) == 1 {
+			return true
+		}
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pat[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, err := path.Match(pat[0], name[0])
+	if err != nil || !ok {
+		return false
+	}
+	return matchSegments(pat[1:], name[1:])
+}
@@ -0,0 +1,76 @@
+package gossip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeIgnoreFile(t *testing.T, dir, name, contents string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMatcherRulePrecedence(t *testing.T) {
+	root, err := ioutil.TempDir("", "gossip_ignore_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	writeIgnoreFile(t, root, ignoreFileName, "*.swp\nnode_modules/\n")
+
+	nested := filepath.Join(root, "posts")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeIgnoreFile(t, nested, ignoreFileName, "!keep.swp\n")
+
+	m, err := NewMatcher(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"foo.swp", false, true},
+		{"node_modules", true, true},
+		{filepath.Join("posts", "draft.swp"), false, true},
+		{filepath.Join("posts", "keep.swp"), false, false},
+		{filepath.Join("posts", "post.md"), false, false},
+	}
+
+	for _, c := range cases {
+		if got := m.Match(c.path, c.isDir); got != c.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", c.path, c.isDir, got, c.want)
+		}
+	}
+}
+
+func TestMatcherDoubleStar(t *testing.T) {
+	root, err := ioutil.TempDir("", "gossip_ignore_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	writeIgnoreFile(t, root, ignoreFileName, "**/*.bak\n")
+
+	m, err := NewMatcher(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.Match(filepath.Join("a", "b", "c.bak"), false) {
+		t.Error("expected nested .bak file to be ignored")
+	}
+	if m.Match(filepath.Join("a", "b", "c.md"), false) {
+		t.Error("expected .md file not to be ignored")
+	}
+}
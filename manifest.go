@@ -0,0 +1,110 @@
+package gossip
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// manifestFileName is where Site persists its build manifest, inside Dest.
+const manifestFileName = ".gossip-cache.json"
+
+// manifestEntry records what produced one output file, so a later run can
+// tell whether it needs to be regenerated.
+type manifestEntry struct {
+	// Hash is the SHA-256 (hex-encoded) of the entry's input. For a copied
+	// file, that's the file's own contents. For a generated post, it's the
+	// post's content hashed together with the template's hash, so changing
+	// either invalidates the output.
+	Hash string `json:"hash"`
+}
+
+// buildManifest is a persisted record of the inputs behind every file in
+// Dest, letting Site.Generate skip unchanged work and remove outputs whose
+// source has disappeared.
+type buildManifest struct {
+	// Entries maps a Dest-relative output path to the entry that produced
+	// it.
+	Entries map[string]manifestEntry `json:"entries"`
+
+	// seen tracks outputs produced on the current run, so entries left
+	// over from a previous run can be identified as orphans and removed.
+	seen map[string]bool
+}
+
+func newBuildManifest() *buildManifest {
+	return &buildManifest{Entries: map[string]manifestEntry{}, seen: map[string]bool{}}
+}
+
+// loadManifest reads the manifest from dest, returning an empty one if it
+// doesn't exist yet or can't be parsed.
+func loadManifest(dest string) *buildManifest {
+	m := newBuildManifest()
+	data, err := ioutil.ReadFile(filepath.Join(dest, manifestFileName))
+	if err != nil {
+		return m
+	}
+	json.Unmarshal(data, m)
+	if m.Entries == nil {
+		m.Entries = map[string]manifestEntry{}
+	}
+	m.seen = map[string]bool{}
+	return m
+}
+
+// save writes m to dest, and removes any output recorded in a previous run
+// but not touched (seen) on this one, since its source no longer exists.
+func (m *buildManifest) save(dest string) error {
+	for rel := range m.Entries {
+		if !m.seen[rel] {
+			os.Remove(filepath.Join(dest, rel))
+			delete(m.Entries, rel)
+		}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dest, manifestFileName), data, 0644)
+}
+
+// upToDate reports whether rel's recorded hash in m already matches hash,
+// and marks rel as seen either way.
+func (m *buildManifest) upToDate(rel, hash string) bool {
+	m.seen[rel] = true
+	entry, ok := m.Entries[rel]
+	return ok && entry.Hash == hash
+}
+
+// record sets rel's hash in m, marking it seen.
+func (m *buildManifest) record(rel, hash string) {
+	m.seen[rel] = true
+	m.Entries[rel] = manifestEntry{Hash: hash}
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hashFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(data), nil
+}
+
+// combineHashes builds a single hash out of several, so e.g. a post's
+// output hash can depend on both its own content and the template that
+// rendered it — changing either invalidates the result.
+func combineHashes(hashes ...string) string {
+	h := sha256.New()
+	for _, s := range hashes {
+		h.Write([]byte(s))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,74 @@
+package gossip
+
+import (
+	"bytes"
+
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/yuin/goldmark"
+	emoji "github.com/yuin/goldmark-emoji"
+	highlighting "github.com/yuin/goldmark-highlighting"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Renderer converts a format's raw source into HTML. Renderers are
+// registered per file extension via RegisterRenderer, so a Site can mix
+// Markdown, Gemtext, and any future format in its posts directory.
+type Renderer interface {
+	Render(input []byte) ([]byte, error)
+}
+
+// renderers maps a file extension (without the leading dot) to the
+// Renderer responsible for it.
+var renderers = map[string]Renderer{}
+
+// RegisterRenderer associates a Renderer with a file extension. Later
+// registrations for the same extension replace earlier ones, so a Site can
+// swap in a custom Renderer for "md" if the built-in Goldmark renderer
+// doesn't fit.
+func RegisterRenderer(ext string, r Renderer) {
+	renderers[ext] = r
+}
+
+func init() {
+	RegisterRenderer("md", NewGoldmarkRenderer(""))
+	RegisterRenderer("txt", NewGoldmarkRenderer(""))
+	RegisterRenderer("gmi", GemtextRenderer{})
+}
+
+// GoldmarkRenderer renders Markdown (with GFM tables, footnotes, task
+// lists, and emoji) to HTML using goldmark, with fenced code blocks
+// highlighted via Chroma.
+type GoldmarkRenderer struct {
+	md goldmark.Markdown
+}
+
+// NewGoldmarkRenderer builds a GoldmarkRenderer whose fenced code blocks
+// are highlighted using the named Chroma style (e.g. "monokai"). An empty
+// style uses "github".
+func NewGoldmarkRenderer(chromaStyle string) *GoldmarkRenderer {
+	if chromaStyle == "" {
+		chromaStyle = "github"
+	}
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			extension.Footnote,
+			emoji.Emoji,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(chromaStyle),
+				highlighting.WithFormatOptions(
+					chromahtml.WithLineNumbers(false),
+				),
+			),
+		),
+	)
+	return &GoldmarkRenderer{md: md}
+}
+
+func (r *GoldmarkRenderer) Render(input []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert(input, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,93 @@
+package gossip
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+// GemtextRenderer renders Gemini "gemtext" source into HTML, so a site can
+// mix .gmi posts in with Markdown ones and have both go through the same
+// template pipeline.
+//
+// Gemtext is a line-oriented format: each line is one of a heading
+// ("#", "##", "###"), a list item ("* "), a link ("=> url [name]"), a
+// blockquote ("> "), a preformat toggle ("```"), or plain text, which is
+// wrapped in a paragraph.
+type GemtextRenderer struct{}
+
+func (GemtextRenderer) Render(input []byte) ([]byte, error) {
+	var out bytes.Buffer
+	var inPre bool
+	var inList bool
+
+	closeList := func() {
+		if inList {
+			out.WriteString("</ul>\n")
+			inList = false
+		}
+	}
+
+	for _, line := range strings.Split(string(input), "\n") {
+		if strings.HasPrefix(line, "```") {
+			if inPre {
+				out.WriteString("</pre>\n")
+			} else {
+				closeList()
+				out.WriteString("<pre>\n")
+			}
+			inPre = !inPre
+			continue
+		}
+
+		if inPre {
+			out.WriteString(html.EscapeString(line))
+			out.WriteString("\n")
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "###"):
+			closeList()
+			out.WriteString("<h3>" + html.EscapeString(strings.TrimSpace(line[3:])) + "</h3>\n")
+		case strings.HasPrefix(line, "##"):
+			closeList()
+			out.WriteString("<h2>" + html.EscapeString(strings.TrimSpace(line[2:])) + "</h2>\n")
+		case strings.HasPrefix(line, "#"):
+			closeList()
+			out.WriteString("<h1>" + html.EscapeString(strings.TrimSpace(line[1:])) + "</h1>\n")
+		case strings.HasPrefix(line, "* "):
+			if !inList {
+				out.WriteString("<ul>\n")
+				inList = true
+			}
+			out.WriteString("<li>" + html.EscapeString(strings.TrimSpace(line[2:])) + "</li>\n")
+		case strings.HasPrefix(line, "=>"):
+			closeList()
+			out.WriteString(renderGemtextLink(line))
+		case strings.HasPrefix(line, ">"):
+			closeList()
+			out.WriteString("<blockquote>" + html.EscapeString(strings.TrimSpace(line[1:])) + "</blockquote>\n")
+		case strings.TrimSpace(line) == "":
+			closeList()
+		default:
+			closeList()
+			out.WriteString("<p>" + html.EscapeString(line) + "</p>\n")
+		}
+	}
+	closeList()
+
+	return out.Bytes(), nil
+}
+
+// renderGemtextLink renders a "=> url [name]" line into an anchor wrapped
+// in a paragraph, per the Gemini spec's link-line syntax.
+func renderGemtextLink(line string) string {
+	fields := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, "=>")), " ", 2)
+	url := strings.TrimSpace(fields[0])
+	name := url
+	if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+		name = strings.TrimSpace(fields[1])
+	}
+	return "<p><a href=\"" + html.EscapeString(url) + "\">" + html.EscapeString(name) + "</a></p>\n"
+}
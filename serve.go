@@ -0,0 +1,250 @@
+package gossip
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gorilla/websocket"
+)
+
+// liveReloadScript is injected into every served HTML page. It opens a
+// websocket back to the server and reloads the page when notified of a
+// rebuild.
+const liveReloadScript = `<script>
+(function() {
+  var proto = location.protocol === "https:" ? "wss:" : "ws:";
+  var sock = new WebSocket(proto + "//" + location.host + "/__gossip/reload");
+  sock.onmessage = function() { location.reload(); };
+})();
+</script>
+</body>`
+
+// watchDebounce is how long Serve waits after the last filesystem event in
+// a burst before triggering a rebuild.
+const watchDebounce = 100 * time.Millisecond
+
+// Serve runs Generate, serves Dest over HTTP at addr, and watches Source
+// for changes, rebuilding and live-reloading connected browsers when the
+// tree changes.
+func (s *Site) Serve(addr string) error {
+	if err := s.Generate(); err != nil {
+		return err
+	}
+
+	hub := newReloadHub()
+
+	mux := http.NewServeMux()
+	mux.Handle("/__gossip/reload", hub)
+	mux.Handle("/", liveReloadHandler(http.FileServer(http.Dir(s.Dest))))
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := addWatchDirs(watcher, s.Source); err != nil {
+		return err
+	}
+
+	go s.watchLoop(watcher, hub)
+
+	log.Printf("gossip: serving %s on %s", s.Dest, addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// watchLoop debounces filesystem events and triggers a rebuild (full or
+// per-post, depending on what changed) after each burst settles. Everything
+// it touches — the timer and the pending path list — lives on this single
+// goroutine, so there's no need to synchronize: the debounce timer fires
+// into the same select loop rather than running the rebuild on its own
+// goroutine via time.AfterFunc.
+func (s *Site) watchLoop(watcher *fsnotify.Watcher, hub *reloadHub) {
+	var pending []string
+
+	timer := time.NewTimer(watchDebounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+					watcher.Add(event.Name)
+				}
+			}
+			pending = append(pending, event.Name)
+			timer.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("gossip: watch error: %v", err)
+		case <-timer.C:
+			changed := pending
+			pending = nil
+			if err := s.rebuildFor(changed); err != nil {
+				log.Printf("gossip: rebuild error: %v", err)
+				continue
+			}
+			hub.notify()
+		}
+	}
+}
+
+// rebuildFor, if every changed path is a post under Source/posts, copies
+// changed static files, regenerates the posts whose content changed, and
+// rewrites the feeds; tag, category, archive, and index pages are not
+// regenerated on that path. Otherwise it falls back to a full Generate,
+// since a template or static asset change can affect any output.
+func (s *Site) rebuildFor(changed []string) error {
+	postsDir := filepath.Join(s.Source, "posts")
+	onlyPosts := len(changed) > 0
+	for _, path := range changed {
+		if !strings.HasPrefix(path, postsDir+string(filepath.Separator)) {
+			onlyPosts = false
+			break
+		}
+	}
+
+	if !onlyPosts {
+		return s.Generate()
+	}
+
+	manifest := loadManifest(s.Dest)
+	matcher, err := NewMatcher(s.Source)
+	if err != nil {
+		return err
+	}
+	if err := s.copyTree(manifest, matcher); err != nil {
+		return err
+	}
+	posts, err := s.loadPosts(matcher)
+	if err != nil {
+		return err
+	}
+	if err := s.generatePosts(posts, manifest); err != nil {
+		return err
+	}
+	if err := s.generateFeeds(feedEntriesFromPosts(s, posts)); err != nil {
+		return err
+	}
+	return manifest.save(s.Dest)
+}
+
+// addWatchDirs recursively adds dir and its subdirectories to watcher,
+// since fsnotify only watches the directories it's explicitly told about.
+func addWatchDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// liveReloadHandler wraps h, injecting liveReloadScript just before the
+// closing </body> tag of responses to request paths ending in "/" or
+// ".html"; other responses pass through unchanged.
+func liveReloadHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/") && !strings.HasSuffix(r.URL.Path, ".html") {
+			h.ServeHTTP(w, r)
+			return
+		}
+		rec := &responseRecorder{ResponseWriter: w, buf: &bytes.Buffer{}}
+		h.ServeHTTP(rec, r)
+		body := rec.buf.Bytes()
+		if bytes.Contains(body, []byte("</body>")) {
+			body = bytes.Replace(body, []byte("</body>"), []byte(liveReloadScript), 1)
+		}
+		// The injected script changes the body length, so the
+		// Content-Length the wrapped handler set no longer applies; drop
+		// it and let net/http chunk the response instead.
+		w.Header().Del("Content-Length")
+		if rec.status != 0 {
+			w.WriteHeader(rec.status)
+		}
+		w.Write(body)
+	})
+}
+
+// responseRecorder buffers a handler's response body and status so
+// liveReloadHandler can rewrite the body, and the headers to match,
+// before either reaches the client.
+type responseRecorder struct {
+	http.ResponseWriter
+	buf    *bytes.Buffer
+	status int
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	return r.buf.Write(p)
+}
+
+// reloadHub tracks connected live-reload websocket clients and notifies
+// them all when a rebuild completes.
+type reloadHub struct {
+	upgrader websocket.Upgrader
+	mu       sync.Mutex
+	clients  map[*websocket.Conn]bool
+}
+
+func newReloadHub() *reloadHub {
+	return &reloadHub{clients: map[*websocket.Conn]bool{}}
+}
+
+func (h *reloadHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	h.clients[conn] = true
+	h.mu.Unlock()
+
+	// Drain and discard client messages until the connection closes, so
+	// we notice disconnects and can clean up.
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				h.mu.Lock()
+				delete(h.clients, conn)
+				h.mu.Unlock()
+				conn.Close()
+				return
+			}
+		}
+	}()
+}
+
+func (h *reloadHub) notify() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn := range h.clients {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("reload")); err != nil {
+			conn.Close()
+			delete(h.clients, conn)
+		}
+	}
+}
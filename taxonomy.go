@@ -0,0 +1,185 @@
+package gossip
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultPaginate is how many posts appear per page of a taxonomy or
+// archive listing when Site.Paginate isn't set.
+const defaultPaginate = 10
+
+// Paginator is passed to tag, category, archive, and index templates,
+// giving them the current page's posts along with enough state to render
+// "next/prev" links and a page count.
+type Paginator struct {
+	Posts   []feedEntry
+	Current int // 1-indexed
+	Pages   int
+	Next    int // 0 if there is no next page
+	Prev    int // 0 if there is no previous page
+}
+
+// taxonomyPage is the data passed to tag.html, category.html, archive.html,
+// and index.html. Term is the tag/category slug, the archive year, or empty
+// for the site index.
+type taxonomyPage struct {
+	Site      *Site
+	Term      string
+	Paginator Paginator
+}
+
+var slugNonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns a tag or category name into a URL-safe slug, e.g.
+// "Go Programming" -> "go-programming".
+func slugify(s string) string {
+	slug := slugNonAlnum.ReplaceAllString(strings.ToLower(s), "-")
+	return strings.Trim(slug, "-")
+}
+
+// paginate splits entries into pages of size perPage, in order.
+func paginate(entries []feedEntry, perPage int) []Paginator {
+	if perPage <= 0 {
+		perPage = defaultPaginate
+	}
+	var pages []Paginator
+	total := (len(entries) + perPage - 1) / perPage
+	if total == 0 {
+		total = 1
+	}
+	for page := 1; page <= total; page++ {
+		start := (page - 1) * perPage
+		end := start + perPage
+		if start > len(entries) {
+			start = len(entries)
+		}
+		if end > len(entries) {
+			end = len(entries)
+		}
+		p := Paginator{Posts: entries[start:end], Current: page, Pages: total}
+		if page > 1 {
+			p.Prev = page - 1
+		}
+		if page < total {
+			p.Next = page + 1
+		}
+		pages = append(pages, p)
+	}
+	return pages
+}
+
+// generateTaxonomies groups posts by tag, category, and year, and renders
+// paginated list pages for each using tag.html, category.html, and
+// archive.html, plus a paginated site index using index.html. Any of
+// these templates that's missing from the site's templates dir is simply
+// skipped — taxonomy pages are opt-in.
+func (s *Site) generateTaxonomies(posts []*Post) error {
+	tmplDir := filepath.Join(s.Source, "templates")
+
+	tags := map[string][]*Post{}
+	categories := map[string][]*Post{}
+	years := map[string][]*Post{}
+
+	for _, post := range posts {
+		for _, tag := range post.Tags {
+			slug := slugify(tag)
+			tags[slug] = append(tags[slug], post)
+		}
+		for _, cat := range post.Categories {
+			slug := slugify(cat)
+			categories[slug] = append(categories[slug], post)
+		}
+		year, _ := post.dateParts()
+		years[year] = append(years[year], post)
+	}
+
+	if tmpl, ok, err := s.taxonomyTemplate(tmplDir, "tag.html"); err != nil {
+		return err
+	} else if ok {
+		for slug, grouped := range tags {
+			if err := s.generateTaxonomyPages(tmpl, grouped, slug, filepath.Join(s.Dest, "tags", slug)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if tmpl, ok, err := s.taxonomyTemplate(tmplDir, "category.html"); err != nil {
+		return err
+	} else if ok {
+		for slug, grouped := range categories {
+			if err := s.generateTaxonomyPages(tmpl, grouped, slug, filepath.Join(s.Dest, "categories", slug)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if tmpl, ok, err := s.taxonomyTemplate(tmplDir, "archive.html"); err != nil {
+		return err
+	} else if ok {
+		for year, grouped := range years {
+			if err := s.generateTaxonomyPages(tmpl, grouped, year, filepath.Join(s.Dest, year)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if tmpl, ok, err := s.taxonomyTemplate(tmplDir, "index.html"); err != nil {
+		return err
+	} else if ok {
+		if err := s.generateTaxonomyPages(tmpl, posts, "", s.Dest); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// taxonomyTemplate loads name from the site's templates dir, returning
+// ok=false (and no error) if it doesn't exist.
+func (s *Site) taxonomyTemplate(tmplDir, name string) (*template.Template, bool, error) {
+	path := filepath.Join(tmplDir, name)
+	if !exists(path) {
+		return nil, false, nil
+	}
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return nil, false, err
+	}
+	return tmpl, true, nil
+}
+
+// generateTaxonomyPages renders one paginated listing of posts under
+// baseDir: page 1 at baseDir/index.html, subsequent pages at
+// baseDir/page/N/index.html.
+func (s *Site) generateTaxonomyPages(tmpl *template.Template, posts []*Post, term string, baseDir string) error {
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].pubdate.After(posts[j].pubdate)
+	})
+	entries := feedEntriesFromPosts(s, posts)
+
+	for _, p := range paginate(entries, s.Paginate) {
+		dir := baseDir
+		if p.Current > 1 {
+			dir = filepath.Join(baseDir, "page", strconv.Itoa(p.Current))
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(filepath.Join(dir, "index.html"))
+		if err != nil {
+			return err
+		}
+		err = tmpl.Execute(f, taxonomyPage{Site: s, Term: term, Paginator: p})
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}